@@ -0,0 +1,481 @@
+package segments
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/jandedobbeleer/oh-my-posh/src/properties"
+	"github.com/jandedobbeleer/oh-my-posh/src/runtime"
+)
+
+const (
+	// BranchMaxLength truncates the branch name to this number of characters.
+	BranchMaxLength properties.Property = "branch_max_length"
+	// TruncateSymbol is appended when the branch name is truncated.
+	TruncateSymbol properties.Property = "truncate_symbol"
+	// FullBranchPath keeps the full branch name, including path separators.
+	// When disabled, only the last path segment is rendered.
+	FullBranchPath properties.Property = "full_branch_path"
+	// MappedBranches replaces a branch name matching a glob-style pattern
+	// ("feature/*") with a custom prefix.
+	MappedBranches properties.Property = "mapped_branches"
+	// BranchPatterns rewrites the branch name using one or more
+	// "pattern:replacement" entries, where replacement is a submatch index
+	// or a substitution template, see Git.applyBranchPatterns.
+	BranchPatterns properties.Property = "branch_patterns"
+	// BranchDescriptionMaxLength truncates BranchDescription to this number
+	// of characters, mirroring BranchMaxLength.
+	BranchDescriptionMaxLength properties.Property = "branch_description_max_length"
+	// UpstreamRef is the ref Ahead/Behind are calculated against, default
+	// "@{u}" (the tracked upstream). Ignored when PushRemote is set.
+	UpstreamRef properties.Property = "upstream_ref"
+	// PushRemote names a remote whose "<remote>/<branch>" ref Ahead/Behind
+	// are calculated against instead of UpstreamRef, for displaying
+	// divergence from a push target rather than the tracked upstream.
+	PushRemote properties.Property = "push_remote"
+)
+
+const defaultUpstreamRef = "@{u}"
+
+// Git is the segment that renders information about the current repository,
+// whichever SCM backend is used to gather it.
+type Git struct {
+	scm
+
+	backend scmBackend
+
+	// BranchDescription is the description set on the current branch via
+	// `git branch --edit-description`, collapsed to a single line.
+	BranchDescription string
+
+	// Branch is the current branch's short name, resolved by the backend.
+	Branch string
+
+	// WorktreeName is the name of the current linked worktree, empty for
+	// the main worktree and for non-worktree checkouts.
+	WorktreeName string
+	// MainWorktreePath is the filesystem path of the repository's main
+	// working tree: the current directory itself for the main worktree and
+	// for a submodule (each is its own repository with a single working
+	// tree), the linked worktree's parent repository for a linked
+	// worktree, and empty for a bare repository, which has no working
+	// tree at all.
+	MainWorktreePath string
+	// IsWorktree reports whether the current directory is a linked
+	// worktree rather than the main working tree.
+	IsWorktree bool
+	// IsSubmodule reports whether the current directory is a git
+	// submodule checkout.
+	IsSubmodule bool
+	// IsBare reports whether the current directory is a bare repository.
+	IsBare bool
+	// LinkedWorktreeCount is the number of linked worktrees attached to
+	// this repository, not counting the main one.
+	LinkedWorktreeCount int
+
+	// Ahead and Behind count commits the current branch leads/trails the
+	// resolved upstream ref by. Both are -1, with UpstreamGone set, when
+	// that ref can't be resolved (no upstream configured, remote gone, ...).
+	Ahead        int
+	Behind       int
+	UpstreamGone bool
+
+	// Status is the working copy's changes, gathered by the backend. Nil
+	// when the backend couldn't be queried.
+	Status *ScmStatus
+	// StashCount is the number of stashed changesets.
+	StashCount int
+}
+
+func (g *Git) Init(props properties.Properties, env runtime.Environment) {
+	g.scm.Init(props, env)
+	g.setWorktreeInfo()
+	g.backend = g.resolveBackend()
+
+	if g.backend == nil {
+		return
+	}
+
+	branch, err := g.backend.currentBranch()
+	if err != nil {
+		return
+	}
+
+	g.Branch = branch
+	g.setBranchDescription(branch)
+	g.setAheadBehind(branch)
+	g.setStatus()
+	g.setStashCount()
+}
+
+// setStatus populates Status from the backend, leaving it nil when the
+// backend can't be queried (e.g. the native backend outside a repository).
+func (g *Git) setStatus() {
+	if g.backend == nil {
+		return
+	}
+
+	status, err := g.backend.status()
+	if err != nil {
+		return
+	}
+
+	g.Status = status
+}
+
+// setStashCount populates StashCount from the backend, leaving it at zero
+// when the backend can't be queried or doesn't support stashes.
+func (g *Git) setStashCount() {
+	if g.backend == nil {
+		return
+	}
+
+	count, err := g.backend.stashCount()
+	if err != nil {
+		return
+	}
+
+	g.StashCount = count
+}
+
+// setWorktreeInfo resolves the repository's worktree topology for the
+// current directory and populates the Git segment's worktree fields.
+func (g *Git) setWorktreeInfo() {
+	if g.env == nil {
+		return
+	}
+
+	pwd := g.env.Pwd()
+
+	wt, err := resolveWorktree(osWorktreeFS{}, pwd, g.env.Getenv("GIT_COMMON_DIR"))
+	if err != nil {
+		return
+	}
+
+	g.IsWorktree = wt.isWorktree
+	g.IsSubmodule = wt.isSubmodule
+	g.IsBare = wt.isBare
+	g.LinkedWorktreeCount = wt.linkedCount
+	g.MainWorktreePath = wt.mainWorktreePath
+
+	if !wt.isWorktree {
+		return
+	}
+
+	name := wt.name
+
+	for pattern, value := range g.props.GetKeyValueMap(MappedWorktrees, map[string]string{}) {
+		if remainder, ok := matchMappedBranch(pattern, name); ok {
+			name = value + remainder
+			break
+		}
+	}
+
+	g.WorktreeName = name
+}
+
+// setBranchDescription populates BranchDescription for branch from the
+// backend, rendering an empty string when the branch has no description.
+func (g *Git) setBranchDescription(branch string) {
+	g.BranchDescription = ""
+
+	if g.backend == nil {
+		return
+	}
+
+	description, err := g.backend.branchDescription(branch)
+	if err != nil || len(description) == 0 {
+		return
+	}
+
+	g.BranchDescription = g.formatBranchDescription(description)
+}
+
+// formatBranchDescription collapses a (possibly multi-line) branch
+// description to a single line and truncates it using the same
+// BranchDescriptionMaxLength/TruncateSymbol semantics as formatBranch.
+func (g *Git) formatBranchDescription(description string) string {
+	rawLines := strings.Split(strings.ReplaceAll(description, "\r\n", "\n"), "\n")
+
+	lines := make([]string, 0, len(rawLines))
+
+	for _, line := range rawLines {
+		if line = strings.TrimSpace(line); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	description = strings.Join(lines, " ")
+
+	maxLength := g.props.GetInt(BranchDescriptionMaxLength, 0)
+	symbol := g.props.GetString(TruncateSymbol, "")
+
+	return truncateBranch(description, maxLength, symbol)
+}
+
+// upstreamRef resolves the ref Ahead/Behind are calculated against:
+// PushRemote, combined with branch, takes priority over UpstreamRef.
+func (g *Git) upstreamRef(branch string) string {
+	if remote := g.props.GetString(PushRemote, ""); len(remote) > 0 {
+		return remote + "/" + branch
+	}
+
+	return g.props.GetString(UpstreamRef, defaultUpstreamRef)
+}
+
+// setAheadBehind populates Ahead/Behind for the current branch against the
+// resolved upstream ref, surfacing a resolution failure as UpstreamGone
+// rather than silently reporting zero divergence.
+func (g *Git) setAheadBehind(branch string) {
+	ref := g.upstreamRef(branch)
+
+	if g.backend == nil {
+		g.Ahead, g.Behind, g.UpstreamGone = -1, -1, true
+		return
+	}
+
+	ahead, behind, err := g.backend.aheadBehind(ref)
+	if err != nil {
+		g.Ahead, g.Behind, g.UpstreamGone = -1, -1, true
+		return
+	}
+
+	g.Ahead, g.Behind, g.UpstreamGone = ahead, behind, false
+}
+
+// resolveBackend picks the scmBackend to use for data gathering based on
+// the Backend property. "auto" prefers the native, process-free backend
+// and falls back to shelling out to git when it can't open the repository
+// (bare repos, formats go-git doesn't support, etc.).
+func (g *Git) resolveBackend() scmBackend {
+	if g.env == nil {
+		return nil
+	}
+
+	switch g.backendKind() {
+	case backendNative:
+		return newNativeBackend(g)
+	case backendCLI:
+		return newCLIBackend(g)
+	default:
+		if native := newNativeBackend(g); native.repoRoot() != "" {
+			return native
+		}
+
+		return newCLIBackend(g)
+	}
+}
+
+// formatBranch renders branch for display, applying (in order) branch
+// mapping, full-path trimming, pattern rewriting and length truncation.
+func (g *Git) formatBranch(branch string) string {
+	mapped := false
+
+	for pattern, value := range g.props.GetKeyValueMap(MappedBranches, map[string]string{}) {
+		if remainder, ok := matchMappedBranch(pattern, branch); ok {
+			branch = value + remainder
+			mapped = true
+			break
+		}
+	}
+
+	if !mapped && !g.props.GetBool(FullBranchPath, true) {
+		if idx := strings.LastIndex(branch, "/"); idx >= 0 {
+			branch = branch[idx+1:]
+		}
+	}
+
+	if len(g.props.GetStringArray(BranchPatterns, []string{})) > 0 {
+		branch = g.applyBranchPatterns(branch)
+	}
+
+	maxLength := g.props.GetInt(BranchMaxLength, 0)
+	symbol := g.props.GetString(TruncateSymbol, "")
+
+	return truncateBranch(branch, maxLength, symbol)
+}
+
+// applyBranchPatterns rewrites branch using the first configured pattern
+// that matches. A pattern is a regular expression optionally suffixed with
+// ":replacement". Omitting the replacement, or the colon entirely, selects
+// the whole match. A plain integer replacement selects that submatch by
+// index (0 is the whole match), preserved for backward compatibility. Any
+// other replacement is treated as a substitution template expanded with
+// Go's regexp.Expand semantics, so it can reference multiple (optionally
+// named) capture groups, e.g. "feature/(?P<ticket>[A-Z]+-\d+)-(?P<slug>.*)
+// :${ticket} - ${slug}" ("{ticket}" is also accepted as shorthand for
+// "${ticket}"). An index out of range, or a template that references no
+// group in the "${ref}"/"{ref}" form, references a group that doesn't
+// exist or has unbalanced braces, leaves branch untouched.
+func (g *Git) applyBranchPatterns(branch string) string {
+	patterns := g.props.GetStringArray(BranchPatterns, []string{})
+
+	for _, pattern := range patterns {
+		parts := strings.SplitN(pattern, ":", 2)
+
+		re, err := regexp.Compile(parts[0])
+		if err != nil {
+			continue
+		}
+
+		matches := re.FindStringSubmatchIndex(branch)
+		if matches == nil {
+			continue
+		}
+
+		if len(parts) == 1 || parts[1] == "" {
+			return string(re.ExpandString(nil, "$0", branch, matches))
+		}
+
+		if index, err := strconv.Atoi(parts[1]); err == nil {
+			if index < 0 || index > re.NumSubexp() {
+				return branch
+			}
+
+			return string(re.ExpandString(nil, "${"+parts[1]+"}", branch, matches))
+		}
+
+		replacement, ok := expandBranchTemplate(re, branch, matches, parts[1])
+		if !ok {
+			return branch
+		}
+
+		return replacement
+	}
+
+	return branch
+}
+
+// expandBranchTemplate expands template (a regexp.Expand-style template,
+// additionally accepting "{name}" as shorthand for "${name}") against a
+// regexp match. It reports false, rather than expanding partially, when
+// template has unbalanced braces or references a group that doesn't exist.
+func expandBranchTemplate(re *regexp.Regexp, branch string, matches []int, template string) (string, bool) {
+	if !bracesBalanced(template) {
+		return "", false
+	}
+
+	template = curlyToDollarBraces(template)
+
+	refs := branchTemplateRefPattern.FindAllStringSubmatch(template, -1)
+	if len(refs) == 0 {
+		// Not a plain index and no group reference: most likely a typo
+		// rather than an intentional literal replacement.
+		return "", false
+	}
+
+	if !referencedGroupsExist(re, refs) {
+		return "", false
+	}
+
+	return string(re.ExpandString(nil, template, branch, matches)), true
+}
+
+func bracesBalanced(s string) bool {
+	depth := 0
+
+	for _, r := range s {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+
+			if depth < 0 {
+				return false
+			}
+		}
+	}
+
+	return depth == 0
+}
+
+// curlyToDollarBraces rewrites "{name}" references that aren't already
+// prefixed with "$" into "${name}".
+func curlyToDollarBraces(s string) string {
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		if s[i] == '{' && (i == 0 || s[i-1] != '$') {
+			b.WriteByte('$')
+		}
+
+		b.WriteByte(s[i])
+	}
+
+	return b.String()
+}
+
+var branchTemplateRefPattern = regexp.MustCompile(`\$\{(\w+)\}`)
+
+// referencedGroupsExist verifies that every "${ref}" match found in a
+// template is either a valid submatch index or the name of an existing
+// named capture group.
+func referencedGroupsExist(re *regexp.Regexp, refs [][]string) bool {
+	names := re.SubexpNames()
+
+	for _, m := range refs {
+		ref := m[1]
+
+		if index, err := strconv.Atoi(ref); err == nil {
+			if index < 0 || index > re.NumSubexp() {
+				return false
+			}
+
+			continue
+		}
+
+		found := false
+
+		for _, name := range names {
+			if name == ref {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+func truncateBranch(branch string, maxLength int, symbol string) string {
+	if maxLength <= 0 {
+		return branch
+	}
+
+	runes := []rune(branch)
+	if len(runes) <= maxLength {
+		return branch
+	}
+
+	if len(symbol) == 0 {
+		return string(runes[:maxLength])
+	}
+
+	return string(runes[:maxLength-1]) + symbol
+}
+
+// matchMappedBranch matches branch against a MappedBranches pattern. A
+// trailing "*" matches any suffix and the unmatched remainder is returned
+// so the caller can prefix it with the mapped value; otherwise the pattern
+// must match branch exactly.
+func matchMappedBranch(pattern, branch string) (string, bool) {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		if strings.HasPrefix(branch, prefix) {
+			return branch[len(prefix):], true
+		}
+
+		return "", false
+	}
+
+	if pattern == branch {
+		return "", true
+	}
+
+	return "", false
+}
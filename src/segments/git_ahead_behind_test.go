@@ -0,0 +1,103 @@
+package segments
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jandedobbeleer/oh-my-posh/src/properties"
+	"github.com/jandedobbeleer/oh-my-posh/src/runtime/mock"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newCLIEnv() *mock.Environment {
+	env := new(mock.Environment)
+	env.On("Pwd").Return("/tmp/not-a-repo")
+	env.On("Getenv", "GIT_COMMON_DIR").Return("")
+	env.On("GOOS").Return("")
+	env.On("InWSLSharedDrive").Return(false)
+	env.On("HasCommand", GITCOMMAND).Return(true)
+	env.On("RunCommand", GITCOMMAND, []string{"status", "--porcelain", "-z"}).Return("", nil)
+	env.On("RunCommand", GITCOMMAND, []string{"stash", "list"}).Return("", nil)
+
+	return env
+}
+
+func TestSetAheadBehindCustomUpstreamRef(t *testing.T) {
+	env := newCLIEnv()
+	env.On("RunCommand", GITCOMMAND, []string{"symbolic-ref", "--short", "HEAD"}).Return("main\n", nil)
+	env.On("RunCommand", GITCOMMAND, []string{"config", "--get", "branch.main.description"}).Return("", errors.New("exit status 1"))
+	env.On("RunCommand", GITCOMMAND, []string{"rev-list", "origin/main..HEAD", "--count"}).Return("2\n", nil)
+	env.On("RunCommand", GITCOMMAND, []string{"rev-list", "HEAD..origin/main", "--count"}).Return("0\n", nil)
+
+	g := &Git{}
+	g.Init(properties.Map{
+		Backend:     backendCLI,
+		UpstreamRef: "origin/main",
+	}, env)
+
+	// Init already resolved the current branch and wired Ahead/Behind;
+	// re-running setAheadBehind directly exercises the same path in isolation.
+	g.setAheadBehind("main")
+
+	assert.Equal(t, "main", g.Branch)
+	assert.Equal(t, 2, g.Ahead)
+	assert.Equal(t, 0, g.Behind)
+	assert.False(t, g.UpstreamGone)
+}
+
+func TestSetAheadBehindPushRemote(t *testing.T) {
+	env := newCLIEnv()
+	env.On("RunCommand", GITCOMMAND, []string{"symbolic-ref", "--short", "HEAD"}).Return("master\n", nil)
+	env.On("RunCommand", GITCOMMAND, []string{"config", "--get", "branch.master.description"}).Return("", errors.New("exit status 1"))
+	env.On("RunCommand", GITCOMMAND, []string{"rev-list", "upstream/master..HEAD", "--count"}).Return("1\n", nil)
+	env.On("RunCommand", GITCOMMAND, []string{"rev-list", "HEAD..upstream/master", "--count"}).Return("3\n", nil)
+
+	g := &Git{}
+	g.Init(properties.Map{
+		Backend:    backendCLI,
+		PushRemote: "upstream",
+	}, env)
+
+	g.setAheadBehind("master")
+
+	assert.Equal(t, "master", g.Branch)
+	assert.Equal(t, 1, g.Ahead)
+	assert.Equal(t, 3, g.Behind)
+	assert.False(t, g.UpstreamGone)
+}
+
+func TestSetAheadBehindResolutionFailure(t *testing.T) {
+	env := newCLIEnv()
+	env.On("RunCommand", GITCOMMAND, []string{"symbolic-ref", "--short", "HEAD"}).Return("main\n", nil)
+	env.On("RunCommand", GITCOMMAND, []string{"config", "--get", "branch.main.description"}).Return("", errors.New("exit status 1"))
+	env.On("RunCommand", GITCOMMAND, []string{"rev-list", "@{u}..HEAD", "--count"}).Return("", errors.New("no upstream configured for branch"))
+
+	g := &Git{}
+	g.Init(properties.Map{Backend: backendCLI}, env)
+
+	g.setAheadBehind("main")
+
+	assert.Equal(t, -1, g.Ahead)
+	assert.Equal(t, -1, g.Behind)
+	assert.True(t, g.UpstreamGone)
+}
+
+// TestInitWiresAheadBehind verifies Init itself resolves the current branch
+// and populates Ahead/Behind end-to-end, rather than requiring callers to
+// invoke setAheadBehind separately.
+func TestInitWiresAheadBehind(t *testing.T) {
+	env := newCLIEnv()
+	env.On("RunCommand", GITCOMMAND, []string{"symbolic-ref", "--short", "HEAD"}).Return("main\n", nil)
+	env.On("RunCommand", GITCOMMAND, []string{"config", "--get", "branch.main.description"}).Return("", errors.New("exit status 1"))
+	env.On("RunCommand", GITCOMMAND, []string{"rev-list", "@{u}..HEAD", "--count"}).Return("4\n", nil)
+	env.On("RunCommand", GITCOMMAND, []string{"rev-list", "HEAD..@{u}", "--count"}).Return("1\n", nil)
+
+	g := &Git{}
+	g.Init(properties.Map{Backend: backendCLI}, env)
+
+	assert.Equal(t, "main", g.Branch)
+	assert.Equal(t, 4, g.Ahead)
+	assert.Equal(t, 1, g.Behind)
+	assert.False(t, g.UpstreamGone)
+}
@@ -0,0 +1,182 @@
+package segments
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// ErrUnsupported is returned by a scmBackend method the backend cannot
+// service at all (e.g. nativeBackend.stashCount); callers treat it like any
+// other error rather than falling back to a different backend mid-call.
+var ErrUnsupported = errors.New("not supported by this backend")
+
+// scmBackend gathers the raw data the Git segment renders. cliBackend
+// shells out to the git executable; nativeBackend reads the repository
+// in-process using go-git.
+type scmBackend interface {
+	repoRoot() string
+	currentBranch() (string, error)
+	aheadBehind(ref string) (ahead int, behind int, err error)
+	status() (*ScmStatus, error)
+	stashCount() (int, error)
+	branchDescription(branch string) (string, error)
+}
+
+// cliBackend is the original implementation: every call shells out to the
+// git executable resolved by scm.hasCommand.
+type cliBackend struct {
+	git *Git
+}
+
+func newCLIBackend(g *Git) *cliBackend {
+	return &cliBackend{git: g}
+}
+
+func (c *cliBackend) ensureCommand() bool {
+	return c.git.hasCommand(GITCOMMAND)
+}
+
+// run executes a git subcommand from the current directory. git's own
+// .git-file/commondir discovery already resolves HEAD and status correctly
+// inside linked worktrees and submodules, so no --git-dir override is
+// needed (and pinning one to the *common* dir is actively wrong: it makes
+// git treat the invoking directory as the worktree root for an ordinary
+// repo, and for a linked worktree it reads the wrong HEAD, since each
+// worktree's HEAD lives under the common dir's worktrees/<name>/HEAD, not
+// the common dir itself).
+func (c *cliBackend) run(args ...string) (string, error) {
+	return c.git.run(args...)
+}
+
+func (c *cliBackend) repoRoot() string {
+	if !c.ensureCommand() {
+		return ""
+	}
+
+	dir, err := c.run("rev-parse", "--show-toplevel")
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(dir)
+}
+
+func (c *cliBackend) currentBranch() (string, error) {
+	if !c.ensureCommand() {
+		return "", errors.New("git not found")
+	}
+
+	branch, err := c.run("symbolic-ref", "--short", "HEAD")
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(branch), nil
+}
+
+func (c *cliBackend) aheadBehind(ref string) (int, int, error) {
+	if !c.ensureCommand() {
+		return 0, 0, errors.New("git not found")
+	}
+
+	ahead, err := c.run("rev-list", ref+"..HEAD", "--count")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	behind, err := c.run("rev-list", "HEAD.."+ref, "--count")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	aheadCount, _ := strconv.Atoi(strings.TrimSpace(ahead))
+	behindCount, _ := strconv.Atoi(strings.TrimSpace(behind))
+
+	return aheadCount, behindCount, nil
+}
+
+// conflictCodes are the XY porcelain codes git status uses for unmerged
+// entries; unlike every other code neither letter alone identifies them
+// (e.g. "AA"/"DD" would otherwise be read as Added/Deleted).
+var conflictCodes = map[string]bool{
+	"DD": true,
+	"AU": true,
+	"UD": true,
+	"UA": true,
+	"DU": true,
+	"AA": true,
+	"UU": true,
+}
+
+func (c *cliBackend) status() (*ScmStatus, error) {
+	if !c.ensureCommand() {
+		return nil, errors.New("git not found")
+	}
+
+	out, err := c.run("status", "--porcelain", "-z")
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ScmStatus{}
+
+	entries := strings.Split(out, "\x00")
+
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 2 {
+			continue
+		}
+
+		switch {
+		case conflictCodes[entry[:2]]:
+			status.Unmerged++
+		case entry[0] == 'R' || entry[0] == 'C':
+			// A rename/copy entry is followed by the orphan "old path" it
+			// was renamed/copied from; skip it so it isn't re-parsed as a
+			// status entry of its own.
+			status.Moved++
+			i++
+		case entry[0] == 'D' || entry[1] == 'D':
+			status.Deleted++
+		case entry[0] == 'A' || entry[0] == '?':
+			status.Added++
+		case entry[0] == 'M' || entry[1] == 'M':
+			status.Modified++
+		}
+	}
+
+	return status, nil
+}
+
+func (c *cliBackend) stashCount() (int, error) {
+	if !c.ensureCommand() {
+		return 0, errors.New("git not found")
+	}
+
+	out, err := c.run("stash", "list")
+	if err != nil {
+		return 0, err
+	}
+
+	if len(strings.TrimSpace(out)) == 0 {
+		return 0, nil
+	}
+
+	return len(strings.Split(strings.TrimSpace(out), "\n")), nil
+}
+
+func (c *cliBackend) branchDescription(branch string) (string, error) {
+	if !c.ensureCommand() {
+		return "", errors.New("git not found")
+	}
+
+	description, err := c.run("config", "--get", "branch."+branch+".description")
+	if err != nil {
+		// git config exits non-zero when the key isn't set; that's not an error for us.
+		return "", nil
+	}
+
+	return description, nil
+}
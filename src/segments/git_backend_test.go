@@ -0,0 +1,71 @@
+package segments
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jandedobbeleer/oh-my-posh/src/properties"
+	"github.com/jandedobbeleer/oh-my-posh/src/runtime/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCLIBackendRunInLinkedWorktreeOmitsGitDirOverride guards against
+// cliBackend.run pinning --git-dir to the resolved common directory: doing
+// so reads the wrong HEAD for a linked worktree, since each worktree's HEAD
+// lives under the common dir's worktrees/<name>/HEAD, not the common dir
+// itself. If run() added that override, the symbolic-ref call below
+// wouldn't match this mock and the test would panic on an unexpected call.
+func TestCLIBackendRunInLinkedWorktreeOmitsGitDirOverride(t *testing.T) {
+	mainRepo := t.TempDir()
+	linked := t.TempDir()
+
+	commonDir := filepath.Join(mainRepo, ".git")
+	worktreeAdminDir := filepath.Join(commonDir, "worktrees", "feature")
+
+	require.NoError(t, os.MkdirAll(worktreeAdminDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(worktreeAdminDir, "commondir"), []byte("../.."), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(linked, ".git"), []byte("gitdir: "+worktreeAdminDir), 0o644))
+
+	env := new(mock.Environment)
+	env.On("Pwd").Return(linked)
+	env.On("Getenv", "GIT_COMMON_DIR").Return("")
+	env.On("GOOS").Return("")
+	env.On("InWSLSharedDrive").Return(false)
+	env.On("HasCommand", GITCOMMAND).Return(true)
+	env.On("RunCommand", GITCOMMAND, []string{"symbolic-ref", "--short", "HEAD"}).Return("feature\n", nil)
+	env.On("RunCommand", GITCOMMAND, []string{"config", "--get", "branch.feature.description"}).Return("", assert.AnError)
+	env.On("RunCommand", GITCOMMAND, []string{"rev-list", "@{u}..HEAD", "--count"}).Return("", assert.AnError)
+	env.On("RunCommand", GITCOMMAND, []string{"status", "--porcelain", "-z"}).Return("", nil)
+	env.On("RunCommand", GITCOMMAND, []string{"stash", "list"}).Return("", nil)
+
+	g := &Git{}
+	g.Init(properties.Map{Backend: backendCLI}, env)
+
+	assert.Equal(t, "feature", g.Branch)
+	assert.True(t, g.IsWorktree)
+}
+
+// TestCLIBackendStatusParsing guards two porcelain-parsing bugs: the orphan
+// "old path" token that trails a rename/copy entry must not be re-parsed as
+// a status entry of its own, and a conflict code like "AA"/"DD" must count
+// as Unmerged rather than Added/Deleted.
+func TestCLIBackendStatusParsing(t *testing.T) {
+	env := new(mock.Environment)
+	env.On("HasCommand", GITCOMMAND).Return(true)
+	env.On("RunCommand", GITCOMMAND, []string{"status", "--porcelain", "-z"}).
+		Return("R  new.txt\x00old.txt\x00AA conflicted.txt\x00 D deleted.txt\x00?? untracked.txt\x00", nil)
+
+	backend := newCLIBackend(&Git{scm: scm{env: env, command: GITCOMMAND}})
+
+	status, err := backend.status()
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, status.Moved)
+	assert.Equal(t, 1, status.Unmerged)
+	assert.Equal(t, 1, status.Deleted)
+	assert.Equal(t, 1, status.Added)
+	assert.Equal(t, 0, status.Modified)
+}
@@ -0,0 +1,66 @@
+package segments
+
+import (
+	"testing"
+
+	"github.com/jandedobbeleer/oh-my-posh/src/properties"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatBranchDescription(t *testing.T) {
+	cases := []struct {
+		Case                       string
+		Input                      string
+		Expected                   string
+		TruncateSymbol             string
+		BranchDescriptionMaxLength int
+	}{
+		{
+			Case:     "Empty description",
+			Input:    "",
+			Expected: "",
+		},
+		{
+			Case:     "Single line",
+			Input:    "adds the new onboarding flow",
+			Expected: "adds the new onboarding flow",
+		},
+		{
+			Case:     "Multi-line collapses to a single line",
+			Input:    "adds the new onboarding flow\n\nfixes #42",
+			Expected: "adds the new onboarding flow fixes #42",
+		},
+		{
+			Case:                       "Truncated without symbol",
+			Input:                      "adds the new onboarding flow",
+			BranchDescriptionMaxLength: 5,
+			Expected:                   "adds ",
+		},
+		{
+			Case:                       "Truncated with symbol",
+			Input:                      "adds the new onboarding flow",
+			BranchDescriptionMaxLength: 5,
+			TruncateSymbol:             "…",
+			Expected:                   "adds…",
+		},
+	}
+
+	for _, tc := range cases {
+		props := properties.Map{
+			BranchDescriptionMaxLength: tc.BranchDescriptionMaxLength,
+			TruncateSymbol:             tc.TruncateSymbol,
+		}
+
+		g := &Git{}
+		g.Init(props, nil)
+
+		if len(tc.Input) == 0 {
+			assert.Empty(t, g.formatBranchDescription(tc.Input), tc.Case)
+			continue
+		}
+
+		got := g.formatBranchDescription(tc.Input)
+		assert.Equal(t, tc.Expected, got, tc.Case)
+	}
+}
@@ -0,0 +1,285 @@
+package segments
+
+import (
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// nativeBackend reads the repository in-process with go-git, avoiding a
+// process spawn per prompt. Git stashes aren't modeled by go-git, so
+// stashCount always reports ErrUnsupported; Git.setStashCount treats that
+// the same as any other failure and leaves StashCount at zero, since
+// backend selection happens once in Git.resolveBackend and isn't revisited
+// per method call.
+type nativeBackend struct {
+	git  *Git
+	repo *git.Repository
+}
+
+func newNativeBackend(g *Git) *nativeBackend {
+	n := &nativeBackend{git: g}
+
+	repo, err := git.PlainOpenWithOptions(g.env.Pwd(), &git.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return n
+	}
+
+	n.repo = repo
+
+	return n
+}
+
+func (n *nativeBackend) repoRoot() string {
+	if n.repo == nil {
+		return ""
+	}
+
+	worktree, err := n.repo.Worktree()
+	if err != nil {
+		return ""
+	}
+
+	return worktree.Filesystem.Root()
+}
+
+func (n *nativeBackend) currentBranch() (string, error) {
+	if n.repo == nil {
+		return "", ErrUnsupported
+	}
+
+	head, err := n.repo.Head()
+	if err != nil {
+		return "", err
+	}
+
+	return head.Name().Short(), nil
+}
+
+// resolveRef resolves ref the way `git rev-parse` would for the handful of
+// forms the Git segment's UpstreamRef/PushRemote properties produce: the
+// special upstream marker "@{u}", a short remote-tracking ref like
+// "origin/main", a local branch name, or an already fully-qualified ref.
+func (n *nativeBackend) resolveRef(ref string) (*plumbing.Reference, error) {
+	if ref == "@{u}" {
+		return n.resolveUpstream()
+	}
+
+	candidates := []plumbing.ReferenceName{
+		plumbing.ReferenceName(ref),
+		plumbing.ReferenceName("refs/remotes/" + ref),
+		plumbing.ReferenceName("refs/heads/" + ref),
+		plumbing.ReferenceName("refs/tags/" + ref),
+	}
+
+	for _, name := range candidates {
+		if resolved, err := n.repo.Reference(name, true); err == nil {
+			return resolved, nil
+		}
+	}
+
+	return nil, fmt.Errorf("reference not found: %s", ref)
+}
+
+// resolveUpstream resolves "@{u}" to the current branch's configured
+// remote-tracking ref (branch.<name>.remote + branch.<name>.merge), the
+// same configuration `git rev-parse @{u}` reads.
+func (n *nativeBackend) resolveUpstream() (*plumbing.Reference, error) {
+	head, err := n.repo.Head()
+	if err != nil {
+		return nil, err
+	}
+
+	branchName := head.Name().Short()
+
+	branchConfig, err := n.repo.Branch(branchName)
+	if err != nil || len(branchConfig.Remote) == 0 {
+		return nil, fmt.Errorf("no upstream configured for branch %q", branchName)
+	}
+
+	mergeBranch := branchName
+	if branchConfig.Merge.IsBranch() {
+		mergeBranch = branchConfig.Merge.Short()
+	}
+
+	return n.repo.Reference(plumbing.NewRemoteReferenceName(branchConfig.Remote, mergeBranch), true)
+}
+
+func (n *nativeBackend) aheadBehind(ref string) (int, int, error) {
+	if n.repo == nil {
+		return 0, 0, ErrUnsupported
+	}
+
+	head, err := n.repo.Head()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	upstream, err := n.resolveRef(ref)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	excluded, err := n.commitsReachableFromMergeBase(head.Hash(), upstream.Hash())
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ahead, err := n.commitsNotIn(head.Hash(), excluded)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	behind, err := n.commitsNotIn(upstream.Hash(), excluded)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return ahead, behind, nil
+}
+
+// commitsReachableFromMergeBase returns every commit reachable from the
+// merge base(s) of a and b, the shared-history frontier that bounds how far
+// commitsNotIn needs to walk from either tip: anything reachable from a
+// merge base is common to both sides, so neither tip's ahead/behind count
+// needs to walk past it. There can be more than one merge base when the two
+// histories share multiple unrelated common ancestors (criss-cross merges);
+// if they share no history at all, bases is empty and commitsNotIn falls
+// back to walking each tip to completion.
+func (n *nativeBackend) commitsReachableFromMergeBase(a, b plumbing.Hash) (map[plumbing.Hash]bool, error) {
+	commitA, err := n.repo.CommitObject(a)
+	if err != nil {
+		return nil, err
+	}
+
+	commitB, err := n.repo.CommitObject(b)
+	if err != nil {
+		return nil, err
+	}
+
+	bases, err := commitA.MergeBase(commitB)
+	if err != nil {
+		return nil, err
+	}
+
+	reachable := map[plumbing.Hash]bool{}
+
+	for _, base := range bases {
+		if err := n.collectReachable(base.Hash, reachable); err != nil {
+			return nil, err
+		}
+	}
+
+	return reachable, nil
+}
+
+// collectReachable adds from and every one of its ancestors to into,
+// skipping any commit (and its ancestors) already present.
+func (n *nativeBackend) collectReachable(from plumbing.Hash, into map[plumbing.Hash]bool) error {
+	if into[from] {
+		return nil
+	}
+
+	commit, err := n.repo.CommitObject(from)
+	if err != nil {
+		return err
+	}
+
+	into[from] = true
+
+	for _, parent := range commit.ParentHashes {
+		if err := n.collectReachable(parent, into); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commitsNotIn counts commits reachable from from, stopping the walk as
+// soon as it reaches a commit in excluded rather than continuing past it:
+// excluded is the set of commits reachable from the merge base, so anything
+// reachable from an excluded commit is already excluded too. This bounds
+// the walk at the merge-base frontier instead of materializing from's full
+// history, the way `git rev-list A..B --count` does.
+func (n *nativeBackend) commitsNotIn(from plumbing.Hash, excluded map[plumbing.Hash]bool) (int, error) {
+	visited := map[plumbing.Hash]bool{}
+	queue := []plumbing.Hash{from}
+	count := 0
+
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+
+		if visited[hash] || excluded[hash] {
+			continue
+		}
+
+		visited[hash] = true
+		count++
+
+		commit, err := n.repo.CommitObject(hash)
+		if err != nil {
+			return 0, err
+		}
+
+		queue = append(queue, commit.ParentHashes...)
+	}
+
+	return count, nil
+}
+
+func (n *nativeBackend) status() (*ScmStatus, error) {
+	if n.repo == nil {
+		return nil, ErrUnsupported
+	}
+
+	worktree, err := n.repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+
+	gitStatus, err := worktree.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ScmStatus{}
+
+	for _, fileStatus := range gitStatus {
+		switch {
+		case fileStatus.Staging == git.Renamed || fileStatus.Worktree == git.Renamed:
+			status.Moved++
+		case fileStatus.Staging == git.Deleted || fileStatus.Worktree == git.Deleted:
+			status.Deleted++
+		case fileStatus.Staging == git.Added || fileStatus.Worktree == git.Untracked:
+			status.Added++
+		case fileStatus.Staging == git.UpdatedButUnmerged || fileStatus.Worktree == git.UpdatedButUnmerged:
+			status.Unmerged++
+		case fileStatus.Staging == git.Modified || fileStatus.Worktree == git.Modified:
+			status.Modified++
+		}
+	}
+
+	return status, nil
+}
+
+func (n *nativeBackend) stashCount() (int, error) {
+	return 0, ErrUnsupported
+}
+
+func (n *nativeBackend) branchDescription(branch string) (string, error) {
+	if n.repo == nil {
+		return "", ErrUnsupported
+	}
+
+	cfg, err := n.repo.Config()
+	if err != nil {
+		return "", err
+	}
+
+	section := cfg.Raw.Section("branch").Subsection(branch)
+
+	return section.Option("description"), nil
+}
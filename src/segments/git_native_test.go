@@ -0,0 +1,259 @@
+package segments
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/jandedobbeleer/oh-my-posh/src/properties"
+	"github.com/jandedobbeleer/oh-my-posh/src/runtime/mock"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var commitSignature = object.Signature{
+	Name:  "oh-my-posh",
+	Email: "test@ohmyposh.dev",
+	When:  time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+}
+
+// initFixtureRepo creates a throwaway repository on disk with one commit on
+// "master" (go-git's default branch) and a tracked file modified in the
+// working tree but not staged.
+func initFixtureRepo(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	filePath := dir + "/README.md"
+	require.NoError(t, os.WriteFile(filePath, []byte("hello"), 0o644))
+
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	_, err = worktree.Add("README.md")
+	require.NoError(t, err)
+
+	_, err = worktree.Commit("initial", &git.CommitOptions{
+		Author: &commitSignature,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(filePath, []byte("hello world"), 0o644))
+
+	return dir
+}
+
+func TestNativeBackendCurrentBranch(t *testing.T) {
+	dir := initFixtureRepo(t)
+
+	env := new(mock.Environment)
+	env.On("Pwd").Return(dir)
+	env.On("Getenv", "GIT_COMMON_DIR").Return("")
+
+	g := &Git{}
+	g.Init(properties.Map{Backend: backendNative}, env)
+
+	branch, err := g.backend.currentBranch()
+	assert.NoError(t, err)
+	assert.Equal(t, "master", branch)
+}
+
+func TestNativeBackendStatus(t *testing.T) {
+	dir := initFixtureRepo(t)
+
+	env := new(mock.Environment)
+	env.On("Pwd").Return(dir)
+	env.On("Getenv", "GIT_COMMON_DIR").Return("")
+
+	g := &Git{}
+	g.Init(properties.Map{Backend: backendNative}, env)
+
+	status, err := g.backend.status()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, status.Modified)
+}
+
+func TestNativeBackendStashUnsupported(t *testing.T) {
+	dir := initFixtureRepo(t)
+
+	env := new(mock.Environment)
+	env.On("Pwd").Return(dir)
+	env.On("Getenv", "GIT_COMMON_DIR").Return("")
+
+	g := &Git{}
+	g.Init(properties.Map{Backend: backendNative}, env)
+
+	_, err := g.backend.stashCount()
+	assert.ErrorIs(t, err, ErrUnsupported)
+}
+
+// commitOnBranch writes name/content, stages it, and commits it to whatever
+// branch worktree currently has checked out.
+func commitOnBranch(t *testing.T, dir string, worktree *git.Worktree, name, content string) plumbing.Hash {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(dir+"/"+name, []byte(content), 0o644))
+
+	_, err := worktree.Add(name)
+	require.NoError(t, err)
+
+	hash, err := worktree.Commit("add "+name, &git.CommitOptions{Author: &commitSignature})
+	require.NoError(t, err)
+
+	return hash
+}
+
+// TestNativeBackendAheadBehindAcrossMerge guards against the ahead/behind
+// traversal stopping at the first commit shared with the excluded side: a
+// merge commit's first parent reaches the common ancestor before its
+// second parent's unique commits are ever visited, so an early-stopping walk
+// undercounts ahead by the number of commits contributed through the merge.
+func TestNativeBackendAheadBehindAcrossMerge(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commitOnBranch(t, dir, worktree, "a.txt", "a")
+
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: "refs/heads/feature", Create: true}))
+	featureTip := commitOnBranch(t, dir, worktree, "b.txt", "b")
+
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: "refs/heads/master"}))
+	masterTip := commitOnBranch(t, dir, worktree, "c.txt", "c")
+
+	require.NoError(t, os.WriteFile(dir+"/b.txt", []byte("b"), 0o644))
+	_, err = worktree.Add("b.txt")
+	require.NoError(t, err)
+
+	_, err = worktree.Commit("merge feature", &git.CommitOptions{
+		Author:  &commitSignature,
+		Parents: []plumbing.Hash{masterTip, featureTip},
+	})
+	require.NoError(t, err)
+
+	env := new(mock.Environment)
+	env.On("Pwd").Return(dir)
+	env.On("Getenv", "GIT_COMMON_DIR").Return("")
+
+	g := &Git{}
+	g.Init(properties.Map{Backend: backendNative}, env)
+
+	ahead, behind, err := g.backend.aheadBehind("feature")
+	require.NoError(t, err)
+
+	// master's merge commit plus the commit it carried over from master
+	// before the merge are both unique to master relative to feature.
+	assert.Equal(t, 2, ahead)
+	assert.Equal(t, 0, behind)
+}
+
+// TestNativeBackendAheadBehindDefaultUpstream exercises the native backend's
+// "@{u}" resolution against a real branch.<name>.remote/.merge configuration
+// and a fetched remote-tracking ref, the path the CLI-backend-only tests in
+// git_ahead_behind_test.go never touched.
+func TestNativeBackendAheadBehindDefaultUpstream(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	base := commitOnBranch(t, dir, worktree, "a.txt", "a")
+	commitOnBranch(t, dir, worktree, "b.txt", "b")
+
+	remoteRef := plumbing.NewRemoteReferenceName("origin", "master")
+	require.NoError(t, repo.Storer.SetReference(plumbing.NewHashReference(remoteRef, base)))
+
+	_, err = repo.CreateRemote(&config.RemoteConfig{Name: "origin", URLs: []string{"https://example.invalid/repo.git"}})
+	require.NoError(t, err)
+
+	require.NoError(t, repo.CreateBranch(&config.Branch{
+		Name:   "master",
+		Remote: "origin",
+		Merge:  "refs/heads/master",
+	}))
+
+	env := new(mock.Environment)
+	env.On("Pwd").Return(dir)
+	env.On("Getenv", "GIT_COMMON_DIR").Return("")
+
+	g := &Git{}
+	g.Init(properties.Map{Backend: backendNative}, env)
+
+	ahead, behind, err := g.backend.aheadBehind("@{u}")
+	require.NoError(t, err)
+	assert.Equal(t, 1, ahead)
+	assert.Equal(t, 0, behind)
+}
+
+// TestNativeBackendAheadBehindBoundedAtMergeBase guards commitsNotIn's
+// merge-base bounding: it walks shared history once to find the merge base,
+// then each tip's walk must stop there rather than re-walking (or
+// miscounting) the commits both sides already have in common.
+func TestNativeBackendAheadBehindBoundedAtMergeBase(t *testing.T) {
+	dir := t.TempDir()
+
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+
+	worktree, err := repo.Worktree()
+	require.NoError(t, err)
+
+	commitOnBranch(t, dir, worktree, "a.txt", "a")
+	commitOnBranch(t, dir, worktree, "b.txt", "b")
+	commitOnBranch(t, dir, worktree, "c.txt", "c")
+
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: "refs/heads/feature", Create: true}))
+	commitOnBranch(t, dir, worktree, "d.txt", "d")
+	commitOnBranch(t, dir, worktree, "e.txt", "e")
+
+	require.NoError(t, worktree.Checkout(&git.CheckoutOptions{Branch: "refs/heads/master"}))
+	commitOnBranch(t, dir, worktree, "f.txt", "f")
+
+	env := new(mock.Environment)
+	env.On("Pwd").Return(dir)
+	env.On("Getenv", "GIT_COMMON_DIR").Return("")
+
+	g := &Git{}
+	g.Init(properties.Map{Backend: backendNative}, env)
+
+	ahead, behind, err := g.backend.aheadBehind("feature")
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, ahead)
+	assert.Equal(t, 2, behind)
+}
+
+func TestAutoBackendFallsBackToCLIOutsideRepo(t *testing.T) {
+	dir := t.TempDir()
+
+	env := new(mock.Environment)
+	env.On("Pwd").Return(dir)
+	env.On("Getenv", "GIT_COMMON_DIR").Return("")
+	env.On("GOOS").Return("")
+	env.On("InWSLSharedDrive").Return(false)
+	env.On("HasCommand", GITCOMMAND).Return(true)
+	env.On("RunCommand", GITCOMMAND, []string{"symbolic-ref", "--short", "HEAD"}).Return("", errors.New("not a git repository"))
+
+	g := &Git{}
+	g.Init(properties.Map{}, env)
+
+	_, isCLI := g.backend.(*cliBackend)
+	assert.True(t, isCLI, "auto backend should fall back to the CLI backend when no repository is present")
+}
@@ -0,0 +1,184 @@
+package segments
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jandedobbeleer/oh-my-posh/src/properties"
+)
+
+// MappedWorktrees replaces a worktree name matching a glob-style pattern
+// ("release/*") with a custom prefix, mirroring MappedBranches.
+const MappedWorktrees properties.Property = "mapped_worktrees"
+
+// worktreeFS is the minimal filesystem surface git_worktree.go needs,
+// kept separate from runtime.Environment so topology resolution can be
+// exercised against an in-memory fixture.
+type worktreeFS interface {
+	isDir(path string) (bool, error)
+	readFile(path string) (string, error)
+	readDir(path string) ([]string, error)
+}
+
+type osWorktreeFS struct{}
+
+func (osWorktreeFS) isDir(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+
+	return info.IsDir(), nil
+}
+
+func (osWorktreeFS) readFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	return string(data), err
+}
+
+func (osWorktreeFS) readDir(path string) ([]string, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name()
+	}
+
+	return names, nil
+}
+
+// worktreeInfo describes where the current directory sits in a
+// repository's worktree topology.
+type worktreeInfo struct {
+	commonDir string
+	gitDir    string
+	name      string
+	// mainWorktreePath is the filesystem path of the repository's main
+	// working tree: dir itself for the main worktree and for a submodule
+	// (each is its own repository with a single working tree), the linked
+	// worktree's parent repository for a linked worktree, and empty for a
+	// bare repository, which has no working tree at all.
+	mainWorktreePath string
+	isWorktree       bool
+	isSubmodule      bool
+	isBare           bool
+	linkedCount      int
+}
+
+// resolveWorktree inspects dir's .git entry (and, for linked worktrees and
+// submodules, the gitdir file it points to) to determine the repository's
+// topology, honoring GIT_COMMON_DIR when set.
+func resolveWorktree(fs worktreeFS, dir, gitCommonDirOverride string) (*worktreeInfo, error) {
+	dotGit := filepath.Join(dir, ".git")
+
+	isDir, err := fs.isDir(dotGit)
+	if err != nil {
+		// No .git entry: dir may itself be a bare repository's git directory.
+		if _, headErr := fs.readFile(filepath.Join(dir, "HEAD")); headErr != nil {
+			return nil, err
+		}
+
+		// A bare repository has no working tree to point to.
+		return &worktreeInfo{gitDir: dir, commonDir: dir, isBare: true}, nil
+	}
+
+	wt := &worktreeInfo{gitDir: dotGit, commonDir: dotGit, mainWorktreePath: dir}
+
+	if isDir {
+		commonDir := dotGit
+		if resolved, err := readCommonDir(fs, dotGit); err == nil {
+			commonDir = resolved
+		}
+
+		if len(gitCommonDirOverride) > 0 {
+			commonDir = gitCommonDirOverride
+		}
+
+		wt.commonDir = commonDir
+		wt.linkedCount = countLinkedWorktrees(fs, commonDir)
+
+		return wt, nil
+	}
+
+	// A regular .git file means this is either a linked worktree or a
+	// submodule; both store "gitdir: <path>" as their only content.
+	contents, err := fs.readFile(dotGit)
+	if err != nil {
+		return nil, err
+	}
+
+	target := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(contents), "gitdir:"))
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(dir, target)
+	}
+
+	target = filepath.Clean(target)
+	wt.gitDir = target
+	wt.commonDir = target
+
+	switch {
+	case strings.Contains(filepath.ToSlash(target), "/worktrees/"):
+		wt.isWorktree = true
+		wt.name = filepath.Base(target)
+
+		commonDir := target
+		if resolved, err := readCommonDir(fs, target); err == nil {
+			commonDir = resolved
+		}
+
+		if len(gitCommonDirOverride) > 0 {
+			commonDir = gitCommonDirOverride
+		}
+
+		wt.commonDir = commonDir
+		wt.linkedCount = countLinkedWorktrees(fs, commonDir)
+		wt.mainWorktreePath = filepath.Dir(commonDir)
+	case strings.Contains(filepath.ToSlash(target), "/modules/"):
+		// A submodule is its own repository with a single working tree: dir.
+		wt.isSubmodule = true
+		wt.mainWorktreePath = dir
+	}
+
+	if len(gitCommonDirOverride) > 0 {
+		wt.commonDir = gitCommonDirOverride
+	}
+
+	return wt, nil
+}
+
+func readCommonDir(fs worktreeFS, gitDir string) (string, error) {
+	data, err := fs.readFile(filepath.Join(gitDir, "commondir"))
+	if err != nil {
+		return filepath.Clean(gitDir), err
+	}
+
+	common := strings.TrimSpace(data)
+	if !filepath.IsAbs(common) {
+		common = filepath.Join(gitDir, common)
+	}
+
+	return filepath.Clean(common), nil
+}
+
+// countLinkedWorktrees counts the entries under commonDir/worktrees, i.e.
+// every linked worktree attached to this repository besides the main one.
+func countLinkedWorktrees(fs worktreeFS, commonDir string) int {
+	entries, err := fs.readDir(filepath.Join(commonDir, "worktrees"))
+	if err != nil {
+		return 0
+	}
+
+	count := 0
+
+	for _, name := range entries {
+		if isDir, err := fs.isDir(filepath.Join(commonDir, "worktrees", name)); err == nil && isDir {
+			count++
+		}
+	}
+
+	return count
+}
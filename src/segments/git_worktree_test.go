@@ -0,0 +1,209 @@
+package segments
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jandedobbeleer/oh-my-posh/src/properties"
+	"github.com/jandedobbeleer/oh-my-posh/src/runtime/mock"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeWorktreeFS is an in-memory worktreeFS fixture keyed by cleaned path.
+type fakeWorktreeFS struct {
+	dirs  map[string]bool
+	files map[string]string
+	dirls map[string][]string
+}
+
+func newFakeWorktreeFS() *fakeWorktreeFS {
+	return &fakeWorktreeFS{
+		dirs:  map[string]bool{},
+		files: map[string]string{},
+		dirls: map[string][]string{},
+	}
+}
+
+func (f *fakeWorktreeFS) addDir(path string)           { f.dirs[filepath.Clean(path)] = true }
+func (f *fakeWorktreeFS) addFile(path, content string) { f.files[filepath.Clean(path)] = content }
+func (f *fakeWorktreeFS) addDirEntries(path string, names ...string) {
+	f.dirls[filepath.Clean(path)] = names
+}
+
+func (f *fakeWorktreeFS) isDir(path string) (bool, error) {
+	path = filepath.Clean(path)
+	if f.dirs[path] {
+		return true, nil
+	}
+
+	if _, ok := f.files[path]; ok {
+		return false, nil
+	}
+
+	return false, errors.New("no such file or directory")
+}
+
+func (f *fakeWorktreeFS) readFile(path string) (string, error) {
+	content, ok := f.files[filepath.Clean(path)]
+	if !ok {
+		return "", errors.New("no such file or directory")
+	}
+
+	return content, nil
+}
+
+func (f *fakeWorktreeFS) readDir(path string) ([]string, error) {
+	names, ok := f.dirls[filepath.Clean(path)]
+	if !ok {
+		return nil, errors.New("no such file or directory")
+	}
+
+	return names, nil
+}
+
+func TestResolveWorktreeLinkedWorktree(t *testing.T) {
+	fs := newFakeWorktreeFS()
+
+	mainRepo := "/repo"
+	linked := "/repo-feature"
+	commonDir := filepath.Join(mainRepo, ".git")
+
+	fs.addFile(filepath.Join(linked, ".git"), "gitdir: "+filepath.Join(commonDir, "worktrees", "feature"))
+	fs.addFile(filepath.Join(commonDir, "worktrees", "feature", "commondir"), "../..")
+	fs.addDir(commonDir)
+	fs.addDirEntries(filepath.Join(commonDir, "worktrees"), "feature")
+	fs.addDir(filepath.Join(commonDir, "worktrees", "feature"))
+
+	wt, err := resolveWorktree(fs, linked, "")
+	require.NoError(t, err)
+
+	assert.True(t, wt.isWorktree)
+	assert.False(t, wt.isSubmodule)
+	assert.False(t, wt.isBare)
+	assert.Equal(t, "feature", wt.name)
+	assert.Equal(t, commonDir, wt.commonDir)
+	assert.Equal(t, 1, wt.linkedCount)
+	assert.Equal(t, mainRepo, wt.mainWorktreePath)
+}
+
+func TestResolveWorktreeSubmodule(t *testing.T) {
+	fs := newFakeWorktreeFS()
+
+	parent := "/repo"
+	sub := "/repo/vendor/lib"
+	subGitDir := filepath.Join(parent, ".git", "modules", "vendor", "lib")
+
+	fs.addFile(filepath.Join(sub, ".git"), "gitdir: "+subGitDir)
+
+	wt, err := resolveWorktree(fs, sub, "")
+	require.NoError(t, err)
+
+	assert.True(t, wt.isSubmodule)
+	assert.False(t, wt.isWorktree)
+	assert.Equal(t, subGitDir, wt.commonDir)
+	// A submodule is its own repository with a single working tree: itself,
+	// not the parent of its private .git/modules/... gitdir.
+	assert.Equal(t, sub, wt.mainWorktreePath)
+}
+
+func TestResolveWorktreeBareRepo(t *testing.T) {
+	fs := newFakeWorktreeFS()
+
+	bare := "/repo.git"
+	fs.addFile(filepath.Join(bare, "HEAD"), "ref: refs/heads/main\n")
+
+	wt, err := resolveWorktree(fs, bare, "")
+	require.NoError(t, err)
+
+	assert.True(t, wt.isBare)
+	assert.False(t, wt.isWorktree)
+	assert.False(t, wt.isSubmodule)
+	assert.Equal(t, bare, wt.commonDir)
+	// A bare repository has no working tree to point to.
+	assert.Empty(t, wt.mainWorktreePath)
+}
+
+// TestSetWorktreeInfoMappedWorktrees verifies setWorktreeInfo applies
+// MappedWorktrees to the resolved worktree name, mirroring the MappedBranches
+// substitution formatBranch already performs for branch names.
+func TestSetWorktreeInfoMappedWorktrees(t *testing.T) {
+	mainRepo := t.TempDir()
+	linked := t.TempDir()
+
+	commonDir := filepath.Join(mainRepo, ".git")
+	worktreeAdminDir := filepath.Join(commonDir, "worktrees", "review-123")
+
+	require.NoError(t, os.MkdirAll(worktreeAdminDir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(worktreeAdminDir, "commondir"), []byte("../.."), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(linked, ".git"), []byte("gitdir: "+worktreeAdminDir), 0o644))
+
+	env := new(mock.Environment)
+	env.On("Pwd").Return(linked)
+	env.On("Getenv", "GIT_COMMON_DIR").Return("")
+
+	g := &Git{}
+	g.scm.Init(properties.Map{
+		MappedWorktrees: map[string]string{
+			"review-*": "🔍 ",
+		},
+	}, env)
+
+	g.setWorktreeInfo()
+
+	assert.True(t, g.IsWorktree)
+	assert.Equal(t, "🔍 123", g.WorktreeName)
+}
+
+// TestResolveWorktreeGitCommonDirOverride verifies GIT_COMMON_DIR doesn't
+// just relabel wt.commonDir: LinkedWorktreeCount must be counted from the
+// overridden directory too, not the one resolved from disk.
+func TestResolveWorktreeGitCommonDirOverride(t *testing.T) {
+	fs := newFakeWorktreeFS()
+
+	dir := "/repo-feature"
+	fs.addDir(filepath.Join(dir, ".git"))
+	fs.addDirEntries(filepath.Join(dir, ".git", "worktrees"), "on-disk-only")
+	fs.addDir(filepath.Join(dir, ".git", "worktrees", "on-disk-only"))
+
+	fs.addDirEntries(filepath.Join("/elsewhere", ".git", "worktrees"), "a", "b")
+	fs.addDir(filepath.Join("/elsewhere", ".git", "worktrees", "a"))
+	fs.addDir(filepath.Join("/elsewhere", ".git", "worktrees", "b"))
+
+	wt, err := resolveWorktree(fs, dir, "/elsewhere/.git")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/elsewhere/.git", wt.commonDir)
+	assert.Equal(t, 2, wt.linkedCount)
+}
+
+// TestResolveWorktreeGitCommonDirOverrideFromLinkedWorktree verifies the
+// same for a linked worktree, where LinkedWorktreeCount and
+// MainWorktreePath are derived from the commondir file on disk unless
+// GIT_COMMON_DIR overrides it.
+func TestResolveWorktreeGitCommonDirOverrideFromLinkedWorktree(t *testing.T) {
+	fs := newFakeWorktreeFS()
+
+	mainRepo := "/repo"
+	linked := "/repo-feature"
+	commonDir := filepath.Join(mainRepo, ".git")
+
+	fs.addFile(filepath.Join(linked, ".git"), "gitdir: "+filepath.Join(commonDir, "worktrees", "feature"))
+	fs.addFile(filepath.Join(commonDir, "worktrees", "feature", "commondir"), "../..")
+	fs.addDirEntries(filepath.Join(commonDir, "worktrees"), "feature")
+	fs.addDir(filepath.Join(commonDir, "worktrees", "feature"))
+
+	fs.addDirEntries(filepath.Join("/elsewhere", ".git", "worktrees"), "a", "b")
+	fs.addDir(filepath.Join("/elsewhere", ".git", "worktrees", "a"))
+	fs.addDir(filepath.Join("/elsewhere", ".git", "worktrees", "b"))
+
+	wt, err := resolveWorktree(fs, linked, "/elsewhere/.git")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/elsewhere/.git", wt.commonDir)
+	assert.Equal(t, 2, wt.linkedCount)
+	assert.Equal(t, "/elsewhere", wt.mainWorktreePath)
+}
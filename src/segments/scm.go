@@ -0,0 +1,136 @@
+package segments
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jandedobbeleer/oh-my-posh/src/properties"
+	"github.com/jandedobbeleer/oh-my-posh/src/runtime"
+)
+
+const (
+	// NativeFallback falls back to the Linux executable when the Windows
+	// one (e.g. "git.exe") isn't available on a WSL shared drive.
+	NativeFallback properties.Property = "native_fallback"
+	// Backend selects the implementation used to gather SCM data: "cli"
+	// shells out to the native tool, "native" uses an in-process
+	// implementation, and "auto" (the default) prefers native and falls
+	// back to the CLI when the native backend can't open the repository.
+	// The choice is made once; a native call that fails later is not
+	// retried through the CLI.
+	Backend properties.Property = "backend"
+
+	backendCLI    = "cli"
+	backendNative = "native"
+	backendAuto   = "auto"
+
+	// GITCOMMAND is the name of the git executable, platform suffix excluded.
+	GITCOMMAND = "git"
+)
+
+// ScmStatus represents the status of a working copy in a way that's
+// agnostic to the underlying SCM and the backend used to gather it.
+type ScmStatus struct {
+	Formats  map[string]string
+	Unmerged int
+	Deleted  int
+	Added    int
+	Modified int
+	Moved    int
+}
+
+func (s *ScmStatus) Changed() bool {
+	return s.Added > 0 ||
+		s.Deleted > 0 ||
+		s.Modified > 0 ||
+		s.Moved > 0 ||
+		s.Unmerged > 0
+}
+
+func (s *ScmStatus) String() string {
+	var builder strings.Builder
+
+	stringIfValue := func(value int, name, symbol string) {
+		if value == 0 {
+			return
+		}
+
+		if format, ok := s.Formats[name]; ok {
+			fmt.Fprintf(&builder, format, value)
+			builder.WriteString(" ")
+			return
+		}
+
+		fmt.Fprintf(&builder, "%s%d ", symbol, value)
+	}
+
+	stringIfValue(s.Added, "Added", "+")
+	stringIfValue(s.Deleted, "Deleted", "-")
+	stringIfValue(s.Modified, "Modified", "~")
+	stringIfValue(s.Moved, "Moved", ">")
+	stringIfValue(s.Unmerged, "Unmerged", "x")
+
+	return strings.TrimSpace(builder.String())
+}
+
+// scm holds the behavior shared by every source control segment: resolving
+// which executable to call (including the WSL shared-drive quirks) and,
+// since the native backend work, the backend used to gather data.
+type scm struct {
+	props   properties.Properties
+	env     runtime.Environment
+	command string
+}
+
+func (s *scm) Init(props properties.Properties, env runtime.Environment) {
+	s.props = props
+	s.env = env
+}
+
+// hasCommand resolves and caches the executable to use for command, taking
+// into account the Windows ".exe" suffix and the WSL2 shared-drive fallback,
+// where invoking the Windows binary directly is dramatically faster than the
+// Linux one mounted over 9p.
+func (s *scm) hasCommand(command string) bool {
+	if len(s.command) > 0 {
+		return true
+	}
+
+	s.command = command
+	if s.env.GOOS() == runtime.WINDOWS {
+		s.command += ".exe"
+	}
+
+	if !s.env.InWSLSharedDrive() {
+		return s.env.HasCommand(s.command)
+	}
+
+	windowsCommand := command + ".exe"
+	if s.env.HasCommand(windowsCommand) {
+		s.command = windowsCommand
+		return true
+	}
+
+	if !s.props.GetBool(NativeFallback, false) {
+		return false
+	}
+
+	s.command = command
+	return s.env.HasCommand(s.command)
+}
+
+func (s *scm) run(args ...string) (string, error) {
+	return s.env.RunCommand(s.command, args...)
+}
+
+// backendKind resolves the configured Backend property, defaulting to auto.
+func (s *scm) backendKind() string {
+	switch s.props.GetString(Backend, backendAuto) {
+	case backendNative:
+		return backendNative
+	case backendCLI:
+		return backendCLI
+	default:
+		return backendAuto
+	}
+}
@@ -319,6 +319,49 @@ func TestBranchPatterns(t *testing.T) {
 				"bug/*":  "🐛 ",
 			},
 		},
+		{
+			Case:  "Single named group",
+			Input: "feature/PROJ-123-my-new-feature",
+			BranchPatterns: []string{
+				`feature/(?P<ticket>[A-Z]+-\d+).*:${ticket}`,
+			},
+			Expected: "PROJ-123",
+		},
+		{
+			Case:  "Multiple named groups joined by literal text",
+			Input: "feature/PROJ-123-my-new-feature",
+			BranchPatterns: []string{
+				`feature/(?P<ticket>[A-Z]+-\d+)-(?P<slug>.*):{ticket} · {slug}`,
+			},
+			Expected: "PROJ-123 · my-new-feature",
+		},
+		{
+			Case:  "Named group template mixed with MappedBranches prefix",
+			Input: "feature/PROJ-123-my-new-feature",
+			BranchPatterns: []string{
+				`.* (?P<ticket>[A-Z]+-\d+).*:{ticket}`,
+			},
+			MappedBranches: map[string]string{
+				"feature/*": "🎟️ ",
+			},
+			Expected: "PROJ-123",
+		},
+		{
+			Case:  "Invalid template, unbalanced braces",
+			Input: "feature/PROJ-123-my-new-feature",
+			BranchPatterns: []string{
+				`feature/(?P<ticket>[A-Z]+-\d+).*:{ticket`,
+			},
+			Expected: "feature/PROJ-123-my-new-feature",
+		},
+		{
+			Case:  "Invalid template, unknown named group",
+			Input: "feature/PROJ-123-my-new-feature",
+			BranchPatterns: []string{
+				`feature/(?P<ticket>[A-Z]+-\d+).*:{nope}`,
+			},
+			Expected: "feature/PROJ-123-my-new-feature",
+		},
 	}
 
 	for _, tc := range cases {